@@ -0,0 +1,261 @@
+// Package companion spawns per-user ephemeral Docker containers and hands
+// back an access code plus a URL that is reverse-proxied back through
+// the main server, turning it into a lightweight per-session sandboxing
+// gateway.
+package companion
+
+import (
+    "context"
+    "crypto/rand"
+    "encoding/hex"
+    "fmt"
+    "net/http"
+    "net/http/httputil"
+    "net/url"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/docker/docker/api/types"
+    "github.com/docker/docker/api/types/container"
+    "github.com/docker/docker/client"
+    "github.com/docker/go-connections/nat"
+)
+
+// minHostPort/maxHostPort bound the ephemeral port range companion
+// containers are published on.
+const (
+    minHostPort = 32768
+    maxHostPort = 65535
+)
+
+// Instance is a single running companion container.
+type Instance struct {
+    AccessCode  string
+    ContainerID string
+    URL         string
+    HostPort    int
+
+    lastUsed time.Time
+    proxy    *httputil.ReverseProxy
+}
+
+// Manager owns a lazily-initialized Docker client and tracks every
+// container it has spawned, keyed by access code.
+type Manager struct {
+    Image         string
+    ContainerPort string // e.g. "8080", the port the companion image listens on
+    MaxInstances  int    // cap on concurrently live instances, 0 means unbounded
+    TTL           time.Duration
+
+    mu        sync.Mutex
+    cli       *client.Client
+    instances map[string]*Instance
+    nextPort  int
+    freePorts []int
+}
+
+// NewManager builds a Manager for the given image. containerPort is the
+// port the image listens on internally. maxInstances caps how many
+// containers may be live at once (0 means unbounded). TTL is how long an
+// instance may sit idle before the reaper removes it.
+func NewManager(image, containerPort string, maxInstances int, ttl time.Duration) *Manager {
+    return &Manager{
+        Image:         image,
+        ContainerPort: containerPort,
+        MaxInstances:  maxInstances,
+        TTL:           ttl,
+        instances:     make(map[string]*Instance),
+        nextPort:      minHostPort,
+    }
+}
+
+func (m *Manager) dockerClient() (*client.Client, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    if m.cli != nil {
+        return m.cli, nil
+    }
+
+    cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+    if err != nil {
+        return nil, fmt.Errorf("creating docker client: %w", err)
+    }
+    m.cli = cli
+    return cli, nil
+}
+
+func newAccessCode() (string, error) {
+    buf := make([]byte, 16)
+    if _, err := rand.Read(buf); err != nil {
+        return "", fmt.Errorf("generating access code: %w", err)
+    }
+    return hex.EncodeToString(buf), nil
+}
+
+// allocatePort hands back a free host port, reusing one left behind by a
+// reaped instance before growing into the unused tail of the range.
+// Callers must hold m.mu.
+func (m *Manager) allocatePort() (int, error) {
+    if n := len(m.freePorts); n > 0 {
+        port := m.freePorts[n-1]
+        m.freePorts = m.freePorts[:n-1]
+        return port, nil
+    }
+
+    if m.nextPort > maxHostPort {
+        return 0, fmt.Errorf("no companion host ports available in %d-%d", minHostPort, maxHostPort)
+    }
+
+    port := m.nextPort
+    m.nextPort++
+    return port, nil
+}
+
+// releasePort returns a host port to the free list. Callers must hold m.mu.
+func (m *Manager) releasePort(port int) {
+    m.freePorts = append(m.freePorts, port)
+}
+
+// Spawn launches a new container from Image, publishes its port to the
+// host, and returns an Instance whose URL proxies back to it. It refuses
+// to spawn once MaxInstances live containers are already tracked.
+func (m *Manager) Spawn(ctx context.Context) (*Instance, error) {
+    cli, err := m.dockerClient()
+    if err != nil {
+        return nil, err
+    }
+
+    m.mu.Lock()
+    if m.MaxInstances > 0 && len(m.instances) >= m.MaxInstances {
+        m.mu.Unlock()
+        return nil, fmt.Errorf("companion instance limit reached (%d)", m.MaxInstances)
+    }
+    hostPort, err := m.allocatePort()
+    if err != nil {
+        m.mu.Unlock()
+        return nil, err
+    }
+    m.mu.Unlock()
+
+    code, err := newAccessCode()
+    if err != nil {
+        m.mu.Lock()
+        m.releasePort(hostPort)
+        m.mu.Unlock()
+        return nil, err
+    }
+
+    containerPort, err := nat.NewPort("tcp", m.ContainerPort)
+    if err != nil {
+        m.mu.Lock()
+        m.releasePort(hostPort)
+        m.mu.Unlock()
+        return nil, fmt.Errorf("parsing container port %s: %w", m.ContainerPort, err)
+    }
+
+    resp, err := cli.ContainerCreate(ctx, &container.Config{
+        Image:        m.Image,
+        ExposedPorts: nat.PortSet{containerPort: struct{}{}},
+    }, &container.HostConfig{
+        PortBindings: nat.PortMap{
+            containerPort: []nat.PortBinding{{HostIP: "127.0.0.1", HostPort: strconv.Itoa(hostPort)}},
+        },
+    }, nil, nil, fmt.Sprintf("companion-%s", code))
+    if err != nil {
+        m.mu.Lock()
+        m.releasePort(hostPort)
+        m.mu.Unlock()
+        return nil, fmt.Errorf("creating container: %w", err)
+    }
+
+    if err := cli.ContainerStart(ctx, resp.ID, types.ContainerStartOptions{}); err != nil {
+        m.mu.Lock()
+        m.releasePort(hostPort)
+        m.mu.Unlock()
+        return nil, fmt.Errorf("starting container %s: %w", resp.ID, err)
+    }
+
+    prefix := "/companion/" + code
+    target := &url.URL{Scheme: "http", Host: fmt.Sprintf("127.0.0.1:%d", hostPort)}
+    proxy := httputil.NewSingleHostReverseProxy(target)
+    baseDirector := proxy.Director
+    proxy.Director = func(req *http.Request) {
+        req.URL.Path = strings.TrimPrefix(req.URL.Path, prefix)
+        baseDirector(req)
+    }
+
+    inst := &Instance{
+        AccessCode:  code,
+        ContainerID: resp.ID,
+        URL:         prefix + "/",
+        HostPort:    hostPort,
+        lastUsed:    time.Now(),
+        proxy:       proxy,
+    }
+
+    m.mu.Lock()
+    m.instances[code] = inst
+    m.mu.Unlock()
+
+    return inst, nil
+}
+
+// Proxy returns the reverse proxy for a live access code, refreshing its
+// idle clock so the reaper leaves it alone while it's in active use.
+func (m *Manager) Proxy(code string) (*httputil.ReverseProxy, bool) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    inst, ok := m.instances[code]
+    if !ok {
+        return nil, false
+    }
+    inst.lastUsed = time.Now()
+    return inst.proxy, true
+}
+
+// ReapIdle removes and stops any container that has been idle longer
+// than the manager's TTL, returning its host port to the free list. Call
+// it periodically from a background goroutine.
+func (m *Manager) ReapIdle(ctx context.Context) {
+    cli, err := m.dockerClient()
+    if err != nil {
+        return
+    }
+
+    m.mu.Lock()
+    var stale []*Instance
+    for code, inst := range m.instances {
+        if time.Since(inst.lastUsed) > m.TTL {
+            stale = append(stale, inst)
+            delete(m.instances, code)
+            m.releasePort(inst.HostPort)
+        }
+    }
+    m.mu.Unlock()
+
+    for _, inst := range stale {
+        _ = cli.ContainerRemove(ctx, inst.ContainerID, types.ContainerRemoveOptions{Force: true})
+    }
+}
+
+// RunReaper starts a goroutine that calls ReapIdle on the given interval
+// until ctx is cancelled.
+func (m *Manager) RunReaper(ctx context.Context, interval time.Duration) {
+    go func() {
+        ticker := time.NewTicker(interval)
+        defer ticker.Stop()
+
+        for {
+            select {
+            case <-ctx.Done():
+                return
+            case <-ticker.C:
+                m.ReapIdle(ctx)
+            }
+        }
+    }()
+}
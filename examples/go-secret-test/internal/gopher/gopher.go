@@ -0,0 +1,116 @@
+// Package gopher implements a minimal RFC 1436 Gopher server, mirroring
+// net/http's HandleFunc shape so handlers can be registered once and
+// served over both protocols.
+package gopher
+
+import (
+    "bufio"
+    "fmt"
+    "io"
+    "net"
+    "strings"
+    "sync"
+    "time"
+)
+
+// Item types, per RFC 1436 section 3.8.
+const (
+    TypeFile = '0'
+    TypeInfo = 'i'
+    TypeDir  = '1'
+)
+
+// maxSelectorLen bounds how many bytes serve will read while waiting for
+// the terminating newline, so a client can't park a connection by
+// trickling bytes that never include one.
+const maxSelectorLen = 1024
+
+// Request is a single Gopher selector request.
+type Request struct {
+    Selector string
+}
+
+// ResponseWriter lets a handler write either a text document (via Write)
+// or directory-listing lines (via WriteItem).
+type ResponseWriter interface {
+    Write(p []byte) (int, error)
+    WriteItem(itemType byte, display, selector, host string, port int) error
+}
+
+// HandlerFunc handles one Gopher selector, mirroring http.HandlerFunc.
+type HandlerFunc func(w ResponseWriter, r *Request)
+
+var (
+    mu       sync.RWMutex
+    handlers = map[string]HandlerFunc{}
+)
+
+// HandleFunc registers fn for the given selector.
+func HandleFunc(selector string, fn HandlerFunc) {
+    mu.Lock()
+    defer mu.Unlock()
+    handlers[selector] = fn
+}
+
+type responseWriter struct {
+    conn net.Conn
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+    return w.conn.Write(p)
+}
+
+func (w *responseWriter) WriteItem(itemType byte, display, selector, host string, port int) error {
+    _, err := fmt.Fprintf(w.conn, "%c%s\t%s\t%s\t%d\r\n", itemType, display, selector, host, port)
+    return err
+}
+
+// ListenAndServe accepts Gopher connections on addr until the listener
+// errors. host is advertised back to clients in directory-listing lines.
+// readTimeout bounds how long a connection may sit idle before sending
+// its selector line, closing off the slow-loris class of issue.
+func ListenAndServe(addr, host string, readTimeout time.Duration) error {
+    ln, err := net.Listen("tcp", addr)
+    if err != nil {
+        return fmt.Errorf("listening on %s: %w", addr, err)
+    }
+    defer ln.Close()
+
+    for {
+        conn, err := ln.Accept()
+        if err != nil {
+            return fmt.Errorf("accepting connection: %w", err)
+        }
+        go serve(conn, host, readTimeout)
+    }
+}
+
+func serve(conn net.Conn, host string, readTimeout time.Duration) {
+    defer conn.Close()
+
+    conn.SetReadDeadline(time.Now().Add(readTimeout))
+    line, err := bufio.NewReader(io.LimitReader(conn, maxSelectorLen)).ReadString('\n')
+    if err != nil {
+        return
+    }
+    conn.SetReadDeadline(time.Time{})
+
+    selector := strings.TrimRight(line, "\r\n")
+    if selector == "" {
+        selector = "/"
+    }
+
+    mu.RLock()
+    fn, ok := handlers[selector]
+    mu.RUnlock()
+
+    w := &responseWriter{conn: conn}
+    if !ok {
+        w.WriteItem(TypeInfo, "selector not found: "+selector, "", host, 0)
+        fmt.Fprint(conn, ".\r\n")
+        return
+    }
+
+    fn(w, &Request{Selector: selector})
+    fmt.Fprint(conn, ".\r\n")
+}
@@ -0,0 +1,68 @@
+package gopher
+
+import (
+    "bufio"
+    "net"
+    "strings"
+    "testing"
+    "time"
+)
+
+func TestServeDispatchesToRegisteredSelector(t *testing.T) {
+    HandleFunc("/test-text", func(w ResponseWriter, r *Request) {
+        w.Write([]byte("hello\r\n"))
+    })
+
+    client, server := net.Pipe()
+    go serve(server, "localhost", time.Second)
+
+    client.Write([]byte("/test-text\r\n"))
+
+    reply, err := bufio.NewReader(client).ReadString('\n')
+    if err != nil {
+        t.Fatalf("reading reply: %v", err)
+    }
+    if reply != "hello\r\n" {
+        t.Errorf("reply = %q, want %q", reply, "hello\r\n")
+    }
+}
+
+func TestServeTerminatesWithDot(t *testing.T) {
+    HandleFunc("/test-dir", func(w ResponseWriter, r *Request) {
+        w.WriteItem(TypeInfo, "an item", "/selector", "localhost", 70)
+    })
+
+    client, server := net.Pipe()
+    go serve(server, "localhost", time.Second)
+
+    client.Write([]byte("/test-dir\r\n"))
+
+    reader := bufio.NewReader(client)
+    line, _ := reader.ReadString('\n')
+    if !strings.HasPrefix(line, string(TypeInfo)+"an item\t/selector\tlocalhost\t70") {
+        t.Errorf("item line = %q, unexpected format", line)
+    }
+
+    terminator, _ := reader.ReadString('\n')
+    if terminator != ".\r\n" {
+        t.Errorf("terminator = %q, want \".\\r\\n\"", terminator)
+    }
+}
+
+func TestServeUnknownSelector(t *testing.T) {
+    client, server := net.Pipe()
+    go serve(server, "localhost", time.Second)
+
+    client.Write([]byte("/does-not-exist\r\n"))
+
+    reader := bufio.NewReader(client)
+    line, _ := reader.ReadString('\n')
+    if !strings.HasPrefix(line, string(TypeInfo)) {
+        t.Errorf("expected an info line for unknown selector, got %q", line)
+    }
+
+    terminator, _ := reader.ReadString('\n')
+    if terminator != ".\r\n" {
+        t.Errorf("terminator = %q, want \".\\r\\n\"", terminator)
+    }
+}
@@ -0,0 +1,99 @@
+// Package metrics wires the default Go collectors plus a handful of
+// request-scoped counters into Prometheus, so the server is observable
+// without any external agent.
+package metrics
+
+import (
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/promauto"
+    "github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// unmatchedLabel buckets any request whose path isn't one of the paths
+// the server actually registered, so 404s on arbitrary junk paths can't
+// mint unbounded label combinations.
+const unmatchedLabel = "unmatched"
+
+// companionLabel buckets every request proxied through a companion
+// container (chunk0-3's /companion/<access_code>/...), whose access
+// codes are per-instance and would otherwise each become their own
+// label value.
+const companionLabel = "/companion/:code"
+
+var (
+    requestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+        Name: "arcane_requests_total",
+        Help: "Total HTTP requests, labeled by method, path and status.",
+    }, []string{"method", "path", "status"})
+
+    requestsInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+        Name: "arcane_requests_in_flight",
+        Help: "Number of HTTP requests currently being served.",
+    })
+
+    requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+        Name:    "arcane_request_duration_seconds",
+        Help:    "HTTP request latency, labeled by path.",
+        Buckets: prometheus.DefBuckets,
+    }, []string{"path"})
+)
+
+// Handler serves the default Go collectors alongside the counters above,
+// for mounting at /metrics.
+func Handler() http.Handler {
+    return promhttp.Handler()
+}
+
+type statusRecorder struct {
+    http.ResponseWriter
+    status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+    r.status = code
+    r.ResponseWriter.WriteHeader(code)
+}
+
+// pathLabel bounds the cardinality of the path label to known,
+// registered paths plus two catch-all buckets for companion proxy
+// requests and everything else.
+func pathLabel(path string, known map[string]struct{}) string {
+    if strings.HasPrefix(path, "/companion/") {
+        return companionLabel
+    }
+    if _, ok := known[path]; ok {
+        return path
+    }
+    return unmatchedLabel
+}
+
+// NewMiddleware builds a metrics middleware that records request count,
+// in-flight gauge and latency for every request that passes through it.
+// knownPaths is the full set of paths the server actually registered;
+// anything else is bucketed under an "unmatched" label.
+func NewMiddleware(knownPaths []string) func(http.Handler) http.Handler {
+    known := make(map[string]struct{}, len(knownPaths))
+    for _, p := range knownPaths {
+        known[p] = struct{}{}
+    }
+
+    return func(next http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            requestsInFlight.Inc()
+            defer requestsInFlight.Dec()
+
+            start := time.Now()
+            rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+            next.ServeHTTP(rec, r)
+
+            label := pathLabel(r.URL.Path, known)
+            requestDuration.WithLabelValues(label).Observe(time.Since(start).Seconds())
+            requestsTotal.WithLabelValues(r.Method, label, strconv.Itoa(rec.status)).Inc()
+        })
+    }
+}
@@ -0,0 +1,93 @@
+// Package secrets redacts known secret values from response bodies and
+// access logs so individual handlers don't have to remember to do it
+// themselves.
+package secrets
+
+import (
+    "bytes"
+    "net/http"
+    "strings"
+    "sync"
+)
+
+// redactedHeaders have their values masked as "****" in access logs
+// regardless of whether their value matches a known secret.
+var redactedHeaders = []string{"Authorization", "Cookie"}
+
+// Redactor holds the set of known secret values to scrub.
+type Redactor struct {
+    mu     sync.RWMutex
+    values []string
+}
+
+// New builds a Redactor seeded with the given secret values. Empty
+// values are ignored.
+func New(values ...string) *Redactor {
+    r := &Redactor{}
+    r.Add(values...)
+    return r
+}
+
+// Add registers additional secret values to scrub.
+func (r *Redactor) Add(values ...string) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    for _, v := range values {
+        if v != "" {
+            r.values = append(r.values, v)
+        }
+    }
+}
+
+// Redact replaces every occurrence of a known secret value in s with "****".
+func (r *Redactor) Redact(s string) string {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    for _, v := range r.values {
+        s = strings.ReplaceAll(s, v, "****")
+    }
+    return s
+}
+
+// RedactHeaders returns a copy of h with known sensitive headers masked,
+// suitable for access logging.
+func RedactHeaders(h http.Header) http.Header {
+    clone := h.Clone()
+    for _, name := range redactedHeaders {
+        if clone.Get(name) != "" {
+            clone.Set(name, "****")
+        }
+    }
+    return clone
+}
+
+// bufferingWriter captures a handler's body so it can be redacted before
+// being written to the real ResponseWriter.
+type bufferingWriter struct {
+    http.ResponseWriter
+    buf        bytes.Buffer
+    statusCode int
+}
+
+func (w *bufferingWriter) WriteHeader(code int) {
+    w.statusCode = code
+}
+
+func (w *bufferingWriter) Write(b []byte) (int, error) {
+    return w.buf.Write(b)
+}
+
+// Middleware wraps next, buffering its response body and redacting any
+// known secret value before it reaches the client.
+func (r *Redactor) Middleware(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+        bw := &bufferingWriter{ResponseWriter: w}
+        next.ServeHTTP(bw, req)
+
+        if bw.statusCode == 0 {
+            bw.statusCode = http.StatusOK
+        }
+        w.WriteHeader(bw.statusCode)
+        w.Write([]byte(r.Redact(bw.buf.String())))
+    })
+}
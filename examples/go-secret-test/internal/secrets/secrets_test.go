@@ -0,0 +1,50 @@
+package secrets
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "testing"
+)
+
+func TestRedactorRedact(t *testing.T) {
+    r := New("super-secret", "")
+
+    got := r.Redact("token=super-secret;rest=ok")
+    want := "token=****;rest=ok"
+    if got != want {
+        t.Errorf("Redact() = %q, want %q", got, want)
+    }
+}
+
+func TestRedactorMiddlewareRedactsBody(t *testing.T) {
+    r := New("super-secret")
+
+    handler := r.Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+        w.Write([]byte("the secret is super-secret"))
+    }))
+
+    rec := httptest.NewRecorder()
+    handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+    if got := rec.Body.String(); got != "the secret is ****" {
+        t.Errorf("body = %q, want secret redacted", got)
+    }
+}
+
+func TestRedactHeaders(t *testing.T) {
+    h := http.Header{}
+    h.Set("Authorization", "Bearer abc123")
+    h.Set("X-Other", "unchanged")
+
+    redacted := RedactHeaders(h)
+
+    if got := redacted.Get("Authorization"); got != "****" {
+        t.Errorf("Authorization = %q, want ****", got)
+    }
+    if got := redacted.Get("X-Other"); got != "unchanged" {
+        t.Errorf("X-Other = %q, want unchanged", got)
+    }
+    if got := h.Get("Authorization"); got != "Bearer abc123" {
+        t.Errorf("original header was mutated: %q", got)
+    }
+}
@@ -1,27 +1,115 @@
 package main
 
 import (
+    "context"
+    "encoding/json"
+    "flag"
     "fmt"
     "log"
     "net/http"
+    "net/http/pprof"
     "os"
+    "os/signal"
+    "strconv"
+    "strings"
+    "sync/atomic"
+    "syscall"
+    "time"
+
+    "github.com/DraconDev/arcane/examples/go-secret-test/internal/companion"
+    "github.com/DraconDev/arcane/examples/go-secret-test/internal/gopher"
+    "github.com/DraconDev/arcane/examples/go-secret-test/internal/metrics"
+    "github.com/DraconDev/arcane/examples/go-secret-test/internal/secrets"
+    "github.com/joho/godotenv"
+    "gopkg.in/yaml.v3"
 )
 
-func main() {
-    port := os.Getenv("PORT")
-    if port == "" {
-        port = "8080"
+// ready flips to false as soon as shutdown begins, so a readiness probe
+// behind a load balancer stops sending new traffic while in-flight
+// requests are allowed to drain.
+var ready atomic.Bool
+
+// EndpointResponse describes one method/response pairing for a mock path.
+type EndpointResponse struct {
+    Method  string            `yaml:"method"`
+    Content string            `yaml:"content"`
+    Body    string            `yaml:"body"`
+    Status  int               `yaml:"status"`
+    Headers map[string]string `yaml:"headers"`
+}
+
+// MockConfig is the top-level YAML schema: path -> list of responses.
+type MockConfig struct {
+    Endpoints map[string][]EndpointResponse `yaml:"endpoints"`
+}
+
+func loadMockConfig(path string) (*MockConfig, error) {
+    if path == "" {
+        return nil, nil
+    }
+
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, fmt.Errorf("reading config %s: %w", path, err)
+    }
+
+    var cfg MockConfig
+    if err := yaml.Unmarshal(data, &cfg); err != nil {
+        return nil, fmt.Errorf("parsing config %s: %w", path, err)
     }
 
+    return &cfg, nil
+}
+
+// registerMockEndpoints wires every configured path into the default
+// ServeMux, dispatching by method and returning 405 for unmatched methods.
+// It returns the registered paths so other protocols (e.g. Gopher) can
+// reuse the same routing table.
+func registerMockEndpoints(cfg *MockConfig) []string {
+    paths := make([]string, 0, len(cfg.Endpoints))
+    for path, responses := range cfg.Endpoints {
+        paths = append(paths, path)
+        responses := responses
+        http.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+            for _, resp := range responses {
+                if !strings.EqualFold(resp.Method, r.Method) {
+                    continue
+                }
+
+                for k, v := range resp.Headers {
+                    w.Header().Set(k, v)
+                }
+                if resp.Content != "" {
+                    w.Header().Set("Content-Type", resp.Content)
+                }
+
+                status := resp.Status
+                if status == 0 {
+                    status = http.StatusOK
+                }
+                w.WriteHeader(status)
+                fmt.Fprint(w, resp.Body)
+                return
+            }
+
+            http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+        })
+    }
+
+    return paths
+}
+
+// registerDemoEndpoints wires up the hard-coded demo handlers and
+// returns their paths so other protocols (e.g. Gopher) can reuse the
+// same routing table.
+func registerDemoEndpoints() []string {
     http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-        log.Printf("📥 Request: %s %s", r.Method, r.URL.Path)
-        
         secret := os.Getenv("API_TOKEN")
         secretDisplay := "NOT_SET"
         if len(secret) > 4 {
-             secretDisplay = secret[:4] + "****"
+            secretDisplay = secret[:4] + "****"
         } else if secret != "" {
-             secretDisplay = "****"
+            secretDisplay = "****"
         }
 
         fmt.Fprintf(w, "🐹 Hello from Go!\n")
@@ -34,8 +122,256 @@ func main() {
         w.Write([]byte("OK"))
     })
 
-    log.Printf("🚀 Starting Go server on port %s...", port)
-    if err := http.ListenAndServe(":"+port, nil); err != nil {
+    return []string{"/", "/health"}
+}
+
+// registerReadinessEndpoint wires up /health/ready, which flips to
+// failing as soon as shutdown begins. It is registered independently of
+// registerMockEndpoints/registerDemoEndpoints so a readiness probe keeps
+// working no matter which mode the server is running in.
+func registerReadinessEndpoint() {
+    http.HandleFunc("/health/ready", func(w http.ResponseWriter, r *http.Request) {
+        if !ready.Load() {
+            http.Error(w, "shutting down", http.StatusServiceUnavailable)
+            return
+        }
+        w.WriteHeader(http.StatusOK)
+        w.Write([]byte("OK"))
+    })
+}
+
+// registerCompanionEndpoint exposes POST /spawn, which launches a new
+// per-user companion container and hands back its access code and URL,
+// plus a /companion/ prefix that reverse-proxies requests back to the
+// matching container.
+func registerCompanionEndpoint(mgr *companion.Manager) {
+    http.HandleFunc("/spawn", func(w http.ResponseWriter, r *http.Request) {
+        if r.Method != http.MethodPost {
+            http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+            return
+        }
+
+        inst, err := mgr.Spawn(r.Context())
+        if err != nil {
+            log.Printf("spawn failed: %v", err)
+            http.Error(w, "failed to spawn companion", http.StatusInternalServerError)
+            return
+        }
+
+        w.Header().Set("Content-Type", "application/json")
+        json.NewEncoder(w).Encode(map[string]string{
+            "access_code": inst.AccessCode,
+            "url":         inst.URL,
+        })
+    })
+
+    http.HandleFunc("/companion/", func(w http.ResponseWriter, r *http.Request) {
+        code := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/companion/"), "/", 2)[0]
+        proxy, ok := mgr.Proxy(code)
+        if !ok {
+            http.NotFound(w, r)
+            return
+        }
+        proxy.ServeHTTP(w, r)
+    })
+}
+
+// registerGopherRoutes mirrors each HTTP path as a Gopher info selector,
+// reusing the routing table built for the mock/demo server.
+func registerGopherRoutes(paths []string, host string) {
+    for _, p := range paths {
+        p := p
+        gopher.HandleFunc(p, func(w gopher.ResponseWriter, r *gopher.Request) {
+            w.WriteItem(gopher.TypeInfo, fmt.Sprintf("%s is served over HTTP", p), p, host, 0)
+        })
+    }
+}
+
+// newAdminMux builds a ServeMux carrying only pprof's debug handlers, so
+// they can be bound to a separate admin port instead of being exposed
+// publicly on http.DefaultServeMux.
+func newAdminMux() *http.ServeMux {
+    mux := http.NewServeMux()
+    mux.HandleFunc("/debug/pprof/", pprof.Index)
+    mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+    mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+    mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+    mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+    return mux
+}
+
+// loggingMiddleware logs every request with sensitive headers masked.
+func loggingMiddleware(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        headers := secrets.RedactHeaders(r.Header)
+        log.Printf("📥 %s %s auth=%s", r.Method, r.URL.Path, headers.Get("Authorization"))
+        next.ServeHTTP(w, r)
+    })
+}
+
+func envDuration(key string, def time.Duration) time.Duration {
+    val := os.Getenv(key)
+    if val == "" {
+        return def
+    }
+    d, err := time.ParseDuration(val)
+    if err != nil {
+        log.Printf("invalid duration for %s=%q, using default %s: %v", key, val, def, err)
+        return def
+    }
+    return d
+}
+
+func envInt(key string, def int) int {
+    val := os.Getenv(key)
+    if val == "" {
+        return def
+    }
+    n, err := strconv.Atoi(val)
+    if err != nil {
+        log.Printf("invalid int for %s=%q, using default %d: %v", key, val, def, err)
+        return def
+    }
+    return n
+}
+
+func loadEnvFile(path string, explicit bool) {
+    if _, err := os.Stat(path); err != nil {
+        if explicit {
+            log.Fatalf("env file %s not found", path)
+        }
+        return
+    }
+
+    if err := godotenv.Load(path); err != nil {
+        log.Fatalf("loading env file %s: %v", path, err)
+    }
+}
+
+func main() {
+    configFlag := flag.String("config", "", "path to a YAML mock-endpoint config")
+    envFileFlag := flag.String("env-file", ".env", "path to a .env file to load before reading config")
+    flag.Parse()
+
+    explicitEnvFile := false
+    flag.Visit(func(f *flag.Flag) {
+        if f.Name == "env-file" {
+            explicitEnvFile = true
+        }
+    })
+    loadEnvFile(*envFileFlag, explicitEnvFile)
+
+    configPath := *configFlag
+    if configPath == "" {
+        configPath = os.Getenv("ARCANE_CONFIG")
+    }
+
+    port := os.Getenv("PORT")
+    if port == "" {
+        port = "8080"
+    }
+
+    cfg, err := loadMockConfig(configPath)
+    if err != nil {
         log.Fatal(err)
     }
+
+    var routedPaths []string
+    if cfg != nil {
+        routedPaths = registerMockEndpoints(cfg)
+    } else {
+        routedPaths = registerDemoEndpoints()
+    }
+
+    if cfg == nil || cfg.Endpoints["/health/ready"] == nil {
+        registerReadinessEndpoint()
+        routedPaths = append(routedPaths, "/health/ready")
+    }
+
+    gopherHost := os.Getenv("ARCANE_GOPHER_HOST")
+    if gopherHost == "" {
+        gopherHost = "localhost"
+    }
+    gopherPort := os.Getenv("ARCANE_GOPHER_PORT")
+    if gopherPort == "" {
+        gopherPort = "70"
+    }
+    registerGopherRoutes(routedPaths, gopherHost)
+    go func() {
+        gopherReadTimeout := envDuration("ARCANE_GOPHER_READ_TIMEOUT", 10*time.Second)
+        if err := gopher.ListenAndServe(":"+gopherPort, gopherHost, gopherReadTimeout); err != nil {
+            log.Printf("gopher listener stopped: %v", err)
+        }
+    }()
+
+    metricsPaths := append([]string(nil), routedPaths...)
+
+    companionImage := os.Getenv("ARCANE_COMPANION_IMAGE")
+    if companionImage != "" && (cfg == nil || (cfg.Endpoints["/spawn"] == nil && cfg.Endpoints["/companion/"] == nil)) {
+        companionPort := os.Getenv("ARCANE_COMPANION_PORT")
+        if companionPort == "" {
+            companionPort = "8080"
+        }
+        maxInstances := envInt("ARCANE_COMPANION_MAX_INSTANCES", 10)
+        mgr := companion.NewManager(companionImage, companionPort, maxInstances, envDuration("ARCANE_COMPANION_TTL", 30*time.Minute))
+        reaperCtx, cancelReaper := context.WithCancel(context.Background())
+        defer cancelReaper()
+        mgr.RunReaper(reaperCtx, time.Minute)
+        registerCompanionEndpoint(mgr)
+        metricsPaths = append(metricsPaths, "/spawn")
+    }
+
+    if cfg == nil || cfg.Endpoints["/metrics"] == nil {
+        http.Handle("/metrics", metrics.Handler())
+        metricsPaths = append(metricsPaths, "/metrics")
+    }
+
+    if os.Getenv("ARCANE_PPROF") == "1" {
+        adminPort := os.Getenv("ARCANE_ADMIN_PORT")
+        if adminPort == "" {
+            adminPort = "6060"
+        }
+        go func() {
+            log.Printf("🔬 Starting pprof admin server on port %s...", adminPort)
+            if err := http.ListenAndServe(":"+adminPort, newAdminMux()); err != nil {
+                log.Printf("admin listener stopped: %v", err)
+            }
+        }()
+    }
+
+    redactor := secrets.New(os.Getenv("API_TOKEN"))
+
+    srv := &http.Server{
+        Addr:           ":" + port,
+        Handler:        redactor.Middleware(loggingMiddleware(metrics.NewMiddleware(metricsPaths)(http.DefaultServeMux))),
+        ReadTimeout:    envDuration("ARCANE_READ_TIMEOUT", 5*time.Second),
+        WriteTimeout:   envDuration("ARCANE_WRITE_TIMEOUT", 10*time.Second),
+        IdleTimeout:    envDuration("ARCANE_IDLE_TIMEOUT", 120*time.Second),
+        MaxHeaderBytes: envInt("ARCANE_MAX_HEADER_BYTES", http.DefaultMaxHeaderBytes),
+    }
+    ready.Store(true)
+
+    go func() {
+        log.Printf("🚀 Starting Go server on port %s...", port)
+        if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+            log.Fatal(err)
+        }
+    }()
+
+    stop := make(chan os.Signal, 1)
+    signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+    <-stop
+
+    log.Println("🛑 Shutdown signal received, draining in-flight requests...")
+    ready.Store(false)
+
+    shutdownTimeout := envDuration("ARCANE_SHUTDOWN_TIMEOUT", 15*time.Second)
+    ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+    defer cancel()
+
+    if err := srv.Shutdown(ctx); err != nil {
+        log.Fatalf("graceful shutdown failed: %v", err)
+    }
+
+    log.Println("✅ Server stopped cleanly")
 }